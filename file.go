@@ -1,19 +1,21 @@
 package gitfs
 
 import (
+	"bytes"
 	"io"
 	"io/fs"
+	"os"
 	"time"
 
 	"github.com/go-git/go-git/v5/plumbing/object"
 )
 
-func newGitFile(tree *object.Tree, entry *object.TreeEntry) (*gitFile, error) {
+func newGitFile(tree *object.Tree, entry *object.TreeEntry, opts Options) (*gitFile, error) {
 	mode, err := entry.Mode.ToOSFileMode()
 	if err != nil {
 		return nil, err
 	}
-	return &gitFile{tree: tree, entry: entry, mode: mode}, nil
+	return &gitFile{tree: tree, entry: entry, mode: mode, spill: opts.SpillToDiskThreshold}, nil
 }
 
 type gitFile struct {
@@ -23,6 +25,29 @@ type gitFile struct {
 
 	file *object.File
 	r    io.ReadCloser
+
+	// ra is the materialised random-access view of the file's content,
+	// built lazily on the first Seek, ReadAt or ReadSeeker call; see
+	// ensureSeekable. It is either a *bytes.Reader or, once the blob is
+	// larger than spill bytes, a spilled *os.File.
+	ra    randomAccessReader
+	spill int64
+
+	// sub is set when this file represents a submodule entry that
+	// could not be resolved into a tree; see SubmoduleInfo.
+	sub *SubmoduleInfo
+
+	// info is set when this file was opened through a FS built with
+	// NewFromCommit or NewFromCommitCached; see CommitInfo.
+	info *CommitInfo
+}
+
+// randomAccessReader is what ra needs to support Seek, ReadAt and Read
+// from the current position.
+type randomAccessReader interface {
+	io.Reader
+	io.ReaderAt
+	io.Seeker
 }
 
 func (g *gitFile) load() (err error) {
@@ -46,6 +71,9 @@ func (g *gitFile) Read(bs []byte) (int, error) {
 	if g.mode.IsDir() {
 		return 0, io.EOF
 	}
+	if g.ra != nil {
+		return g.ra.Read(bs)
+	}
 	if err := g.load(); err != nil {
 		return 0, err
 	}
@@ -59,7 +87,87 @@ func (g *gitFile) Read(bs []byte) (int, error) {
 	return g.r.Read(bs)
 }
 
+// ensureSeekable materialises the file's content into g.ra, so that
+// Seek, ReadAt and Read can be served from a single random-access view.
+// Blobs larger than g.spill bytes (when positive) are spilled to a
+// temporary file instead of being buffered in memory.
+func (g *gitFile) ensureSeekable() error {
+	if g.ra != nil {
+		return nil
+	}
+	if g.mode.IsDir() {
+		return &fs.PathError{Op: "seek", Path: g.entry.Name, Err: fs.ErrInvalid}
+	}
+	if err := g.load(); err != nil {
+		return err
+	}
+
+	r, err := g.file.Reader()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	if g.spill > 0 && g.file.Size > g.spill {
+		f, err := os.CreateTemp("", "gitfs-*")
+		if err != nil {
+			return err
+		}
+		os.Remove(f.Name())
+		if _, err := io.Copy(f, r); err != nil {
+			f.Close()
+			return err
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			f.Close()
+			return err
+		}
+		g.ra = f
+		return nil
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	g.ra = bytes.NewReader(data)
+	return nil
+}
+
+// Seek implements io.Seeker, materialising the file's content on first
+// use; see ensureSeekable.
+func (g *gitFile) Seek(offset int64, whence int) (int64, error) {
+	if err := g.ensureSeekable(); err != nil {
+		return 0, err
+	}
+	return g.ra.Seek(offset, whence)
+}
+
+// ReadAt implements io.ReaderAt, materialising the file's content on
+// first use; see ensureSeekable.
+func (g *gitFile) ReadAt(p []byte, off int64) (int, error) {
+	if err := g.ensureSeekable(); err != nil {
+		return 0, err
+	}
+	return g.ra.ReadAt(p, off)
+}
+
+// ReadSeeker returns an io.ReadSeeker over the file's content, suitable
+// for use with http.ServeContent or archive/zip.NewReader. It
+// materialises the content as a side effect; see ensureSeekable.
+func (g *gitFile) ReadSeeker() (io.ReadSeeker, error) {
+	if err := g.ensureSeekable(); err != nil {
+		return nil, err
+	}
+	return g.ra, nil
+}
+
 func (g *gitFile) Close() error {
+	if c, ok := g.ra.(io.Closer); ok {
+		if err := c.Close(); err != nil {
+			return err
+		}
+	}
 	if g.r != nil {
 		return g.r.Close()
 	}
@@ -101,7 +209,13 @@ func (g *gitFile) Mode() fs.FileMode {
 	return g.mode
 }
 
+// ModTime returns the time the file was last modified, or the zero
+// time if this file was not opened through a FS built with
+// NewFromCommit or NewFromCommitCached.
 func (g *gitFile) ModTime() time.Time {
+	if g.info != nil {
+		return g.info.Author.When
+	}
 	return time.Time{}
 }
 
@@ -109,6 +223,15 @@ func (g *gitFile) IsDir() bool {
 	return g.mode.IsDir()
 }
 
+// Sys returns a *SubmoduleInfo for an unresolved submodule entry, a
+// *CommitInfo for a file opened through a FS built with NewFromCommit
+// or NewFromCommitCached, or nil otherwise.
 func (g *gitFile) Sys() interface{} {
+	if g.sub != nil {
+		return g.sub
+	}
+	if g.info != nil {
+		return g.info
+	}
 	return nil
 }
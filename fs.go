@@ -1,8 +1,20 @@
+// Package gitfs exposes a git object.Tree as a read-only fs.FS, with
+// optional support for symlinks, submodules and a writable overlay.
+//
+// New builds a lazy view that walks the tree on every access, which
+// is cheapest when only a few paths are ever read. NewIndexed instead
+// walks the whole tree once upfront and serves subsequent accesses
+// from a path-indexed cache, trading that upfront time and the memory
+// to hold one entry per file and directory for O(1) Open, Stat,
+// ReadDir and Sub calls; prefer it when a filesystem will be queried
+// many times, such as behind an HTTP server.
 package gitfs
 
 import (
 	"io/fs"
+	"strings"
 
+	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing/filemode"
 	"github.com/go-git/go-git/v5/plumbing/object"
 )
@@ -13,7 +25,143 @@ func New(tree *object.Tree) fs.FS {
 }
 
 type gitFS struct {
-	tree *object.Tree
+	tree     *object.Tree
+	opts     Options
+	resolver SubmoduleResolver
+
+	modulesLoaded bool
+	modules       *config.Modules
+
+	// index, when non-nil, makes walk resolve paths against a
+	// precomputed map instead of re-walking the tree; see NewIndexed.
+	index *treeIndex
+	// history, when non-nil, supplies ModTime and Sys for files from a
+	// commit's history; see NewFromCommit.
+	history *commitHistory
+	// prefix is this filesystem's root path within index/history, ""
+	// denoting their own root; it is set by Sub when either is shared
+	// with a subtree view.
+	prefix string
+}
+
+// walkResult is what walk found at the end of a path.
+type walkResult struct {
+	// parent is the tree directly containing entry; it's what
+	// TreeEntryFile needs to read entry's blob contents.
+	parent *object.Tree
+	entry  *object.TreeEntry
+	// dirTree is set when entry denotes something that can be read as
+	// a directory: the root, a regular Dir entry, or a resolved
+	// submodule. It is nil for files, symlinks and unresolved
+	// submodules.
+	dirTree *object.Tree
+	// sub is set when entry is a submodule gitlink that could not be
+	// resolved into a tree; see SubmoduleResolver.
+	sub *SubmoduleInfo
+}
+
+// fullPath translates name, relative to g's own root, into the path it
+// is stored under in g.index or g.history, accounting for g.prefix
+// when g is a subtree view returned by Sub.
+func (g *gitFS) fullPath(name string) string {
+	if name == "." {
+		if g.prefix == "" {
+			return "."
+		}
+		return g.prefix
+	}
+	if g.prefix == "" {
+		return name
+	}
+	return g.prefix + "/" + name
+}
+
+// walk resolves name against the root tree, descending into submodules
+// via g.resolver whenever a gitlink entry is crossed, and, when
+// Options.FollowSymlinks is set, through a symlink entry crossed
+// mid-path, so that paths inside a submodule or through a directory
+// symlink can be addressed like any other path.
+func (g *gitFS) walk(name string) (walkResult, error) {
+	if g.index != nil {
+		return g.indexWalk(name)
+	}
+
+	if name == "." {
+		return walkResult{
+			parent:  g.tree,
+			entry:   &object.TreeEntry{Name: ".", Mode: filemode.Dir, Hash: g.tree.Hash},
+			dirTree: g.tree,
+		}, nil
+	}
+
+	tree := g.tree
+	parts := strings.Split(name, "/")
+	for i, part := range parts {
+		entry, err := tree.FindEntry(part)
+		if err != nil {
+			return walkResult{}, err
+		}
+		last := i == len(parts)-1
+
+		if entry.Mode == filemode.Submodule {
+			subPath := strings.Join(parts[:i+1], "/")
+			sub, ok := g.resolveSubmodule(subPath, entry.Hash)
+			if !ok {
+				if !last {
+					return walkResult{}, object.ErrDirectoryNotFound
+				}
+				return walkResult{
+					parent: tree,
+					entry:  &object.TreeEntry{Name: entry.Name, Mode: filemode.Dir, Hash: entry.Hash},
+					sub:    &SubmoduleInfo{Hash: entry.Hash, URL: g.submoduleURL(subPath)},
+				}, nil
+			}
+			if last {
+				return walkResult{
+					parent:  tree,
+					entry:   &object.TreeEntry{Name: entry.Name, Mode: filemode.Dir, Hash: sub.Hash},
+					dirTree: sub,
+				}, nil
+			}
+			tree = sub
+			continue
+		}
+
+		if entry.Mode == filemode.Symlink && !last {
+			if !g.opts.FollowSymlinks {
+				return walkResult{}, object.ErrDirectoryNotFound
+			}
+			subPath := strings.Join(parts[:i+1], "/")
+			resolved, resolvedTree, err := g.resolveSymlink(tree, subPath, entry, 0)
+			if err != nil {
+				return walkResult{}, err
+			}
+			if resolved.Mode != filemode.Dir {
+				return walkResult{}, object.ErrDirectoryNotFound
+			}
+			if tree, err = resolvedTree.Tree(resolved.Name); err != nil {
+				return walkResult{}, err
+			}
+			continue
+		}
+
+		if last {
+			var dirTree *object.Tree
+			if entry.Mode == filemode.Dir {
+				if dirTree, err = tree.Tree(entry.Name); err != nil {
+					return walkResult{}, err
+				}
+			}
+			return walkResult{parent: tree, entry: entry, dirTree: dirTree}, nil
+		}
+
+		if tree, err = tree.Tree(entry.Name); err != nil {
+			return walkResult{}, err
+		}
+	}
+
+	// unreachable: parts always has at least one element.
+	return walkResult{}, fs.ErrInvalid
 }
 
 // Open opens the named file.
@@ -25,27 +173,39 @@ type gitFS struct {
 // Open should reject attempts to open names that do not satisfy
 // ValidPath(name), returning a *PathError with Err set to
 // ErrInvalid or ErrNotExist.
+//
+// If Options.FollowSymlinks was set by NewWithOptions, Open resolves
+// intra-tree symlinks transparently; see resolveSymlink. If the path
+// crosses a submodule that a SubmoduleResolver supplied via
+// NewWithResolver can resolve, Open descends into the pointed-at tree
+// as if it were a regular subdirectory.
 func (g *gitFS) Open(name string) (fs.File, error) {
 	if !fs.ValidPath(name) {
 		return nil, toFSError("open", name, fs.ErrInvalid)
 	}
 
-	var entry *object.TreeEntry
-	switch name {
-	case ".":
-		entry = &object.TreeEntry{Name: ".", Mode: filemode.Dir, Hash: g.tree.Hash}
-	default:
-		var err error
-		entry, err = g.tree.FindEntry(name)
-		if err != nil {
+	wr, err := g.walk(name)
+	if err != nil {
+		return nil, toFSError("open", name, err)
+	}
+
+	entry, tree := wr.entry, wr.parent
+	if g.opts.FollowSymlinks && entry.Mode == filemode.Symlink {
+		if entry, tree, err = g.resolveSymlink(tree, name, entry, 0); err != nil {
 			return nil, toFSError("open", name, err)
 		}
 	}
 
-	file, err := newGitFile(g.tree, entry)
+	file, err := newGitFile(tree, entry, g.opts)
 	if err != nil {
 		return nil, toFSError("open", name, err)
 	}
+	file.sub = wr.sub
+	if g.history != nil {
+		if file.info, err = g.history.info(g.fullPath(name)); err != nil {
+			return nil, toFSError("open", name, err)
+		}
+	}
 	return file, nil
 }
 
@@ -56,28 +216,32 @@ func (g *gitFS) ReadDir(name string) ([]fs.DirEntry, error) {
 		return nil, toFSError("readdir", name, fs.ErrInvalid)
 	}
 
-	var tree *object.Tree
-	switch name {
-	case ".":
-		tree = g.tree
-	default:
-		var err error
-		tree, err = g.tree.Tree(name)
-		if err != nil {
-			if _, err := g.tree.FindEntry(name); err == nil {
-				// regular file returns nil entries.
-				return nil, nil
-			}
-			return nil, toFSError("readdir", name, err)
-		}
+	wr, err := g.walk(name)
+	if err != nil {
+		return nil, toFSError("readdir", name, err)
+	}
+	if wr.dirTree == nil {
+		// a regular file, or a submodule that couldn't be resolved,
+		// reads as an empty directory.
+		return nil, nil
 	}
 
+	tree := wr.dirTree
 	entries := make([]fs.DirEntry, len(tree.Entries))
 	for i, n := 0, len(tree.Entries); i < n; i++ {
-		file, err := newGitFile(tree, &tree.Entries[i])
+		file, err := newGitFile(tree, &tree.Entries[i], g.opts)
 		if err != nil {
 			return nil, toFSError("readdir", name, err)
 		}
+		if g.history != nil {
+			childPath := tree.Entries[i].Name
+			if name != "." {
+				childPath = name + "/" + childPath
+			}
+			if file.info, err = g.history.info(g.fullPath(childPath)); err != nil {
+				return nil, toFSError("readdir", name, err)
+			}
+		}
 		entries[i] = file
 	}
 	return entries, nil
@@ -85,11 +249,24 @@ func (g *gitFS) ReadDir(name string) ([]fs.DirEntry, error) {
 
 // Sub returns an FS corresponding to the subtree rooted at dir.
 func (g *gitFS) Sub(dir string) (fs.FS, error) {
-	tree, err := g.tree.Tree(dir)
+	wr, err := g.walk(dir)
 	if err != nil {
 		return nil, toFSError("sub", dir, err)
 	}
-	return &gitFS{tree: tree}, nil
+	if wr.dirTree == nil {
+		return nil, toFSError("sub", dir, fs.ErrInvalid)
+	}
+
+	sub := &gitFS{tree: wr.dirTree, opts: g.opts, resolver: g.resolver}
+	if g.index != nil || g.history != nil {
+		sub.index = g.index
+		sub.history = g.history
+		sub.prefix = g.fullPath(dir)
+		if sub.prefix == "." {
+			sub.prefix = ""
+		}
+	}
+	return sub, nil
 }
 
 func toFSError(op, name string, err error) error {
@@ -0,0 +1,135 @@
+package gitfs
+
+import (
+	"io/fs"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// CommitInfo describes the commit responsible for the most recent
+// change to a path, as surfaced by FileInfo.Sys on a FS built with
+// NewFromCommit or NewFromCommitCached.
+type CommitInfo struct {
+	// Hash of the commit.
+	Hash plumbing.Hash
+	// Author is the commit's original author.
+	Author object.Signature
+	// Message is the commit message.
+	Message string
+}
+
+// commitHistory is the per-path "last touched by" cache shared by a
+// NewFromCommit filesystem and any FS obtained from it via Sub.
+type commitHistory struct {
+	commit *object.Commit
+	built  bool
+	times  map[string]*CommitInfo
+}
+
+func (h *commitHistory) ensure() error {
+	if h.built {
+		return nil
+	}
+	times, err := walkCommitHistory(h.commit)
+	if err != nil {
+		return err
+	}
+	h.times = times
+	h.built = true
+	return nil
+}
+
+// info returns the CommitInfo for path, building the history cache on
+// first use. A path that was never individually changed along the
+// walk, such as a directory whose only changes came from its
+// descendants, defaults to the commit the history was built from.
+func (h *commitHistory) info(path string) (*CommitInfo, error) {
+	if err := h.ensure(); err != nil {
+		return nil, err
+	}
+	if info, ok := h.times[path]; ok {
+		return info, nil
+	}
+	return &CommitInfo{Hash: h.commit.Hash, Author: h.commit.Author, Message: h.commit.Message}, nil
+}
+
+// NewFromCommit returns a readonly fs.FS based on commit's tree in
+// which FileInfo.ModTime reports when each path was last changed and
+// FileInfo.Sys returns the responsible *CommitInfo. The history is
+// walked along commit's first-parent chain, diffing each commit's tree
+// against its parent's, on the first Open, Stat or ReadDir of any
+// path; later calls reuse the resulting cache. Use NewFromCommitCached
+// to pay that cost upfront instead.
+func NewFromCommit(commit *object.Commit) (fs.FS, error) {
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	return &gitFS{tree: tree, history: &commitHistory{commit: commit}}, nil
+}
+
+// NewFromCommitCached is like NewFromCommit, but walks commit's history
+// immediately instead of deferring it to the first Open, Stat or
+// ReadDir, so the cost is paid once upfront.
+func NewFromCommitCached(commit *object.Commit) (fs.FS, error) {
+	history := &commitHistory{commit: commit}
+	if err := history.ensure(); err != nil {
+		return nil, err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	return &gitFS{tree: tree, history: history}, nil
+}
+
+// walkCommitHistory walks commit's first-parent chain, diffing each
+// commit's tree against its parent's (or, for the root commit, against
+// an empty tree), and records for every changed path the first, i.e.
+// most recent, commit encountered that touched it.
+func walkCommitHistory(commit *object.Commit) (map[string]*CommitInfo, error) {
+	times := make(map[string]*CommitInfo)
+
+	for cur := commit; cur != nil; {
+		tree, err := cur.Tree()
+		if err != nil {
+			return nil, err
+		}
+
+		parent, err := cur.Parent(0)
+		if err != nil && err != object.ErrParentNotFound {
+			return nil, err
+		}
+
+		parentTree := &object.Tree{}
+		if parent != nil {
+			if parentTree, err = parent.Tree(); err != nil {
+				return nil, err
+			}
+		}
+
+		changes, err := parentTree.Diff(tree)
+		if err != nil {
+			return nil, err
+		}
+
+		info := &CommitInfo{Hash: cur.Hash, Author: cur.Author, Message: cur.Message}
+		for _, change := range changes {
+			name := change.To.Name
+			if name == "" {
+				name = change.From.Name
+			}
+			if name == "" {
+				continue
+			}
+			if _, ok := times[name]; !ok {
+				times[name] = info
+			}
+		}
+
+		cur = parent
+	}
+
+	return times, nil
+}
@@ -0,0 +1,115 @@
+package gitfs
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// commitFile commits a single file with the given content on top of
+// whatever is already in the worktree, returning the resulting commit.
+func commitFile(t *testing.T, r *git.Repository, ws, name, content string, when time.Time) *object.Commit {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(ws, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	w, err := r.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.AddWithOptions(&git.AddOptions{All: true}); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := w.Commit("update "+name, &git.CommitOptions{
+		Author: &object.Signature{Name: "gitfs", Email: "gitfs@xxx.com", When: when},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit, err := r.CommitObject(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return commit
+}
+
+func TestNewFromCommit(t *testing.T) {
+	ws := t.TempDir()
+	r, err := git.Init(memory.NewStorage(), osfs.New(ws))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	t1 := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	t2 := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	commitFile(t, r, ws, "a.txt", "a v1", t0)
+	commitFile(t, r, ws, "b.txt", "b v1", t1)
+	head := commitFile(t, r, ws, "a.txt", "a v2", t2)
+
+	for _, ctor := range []struct {
+		name string
+		new  func(*object.Commit) (fs.FS, error)
+	}{
+		{"NewFromCommit", NewFromCommit},
+		{"NewFromCommitCached", NewFromCommitCached},
+	} {
+		t.Run(ctor.name, func(t *testing.T) {
+			gfs, err := ctor.new(head)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			t.Run("a.txt was last touched by the head commit", func(t *testing.T) {
+				info, err := fs.Stat(gfs, "a.txt")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !info.ModTime().Equal(t2) {
+					t.Errorf("expect mod time: %v, got: %v", t2, info.ModTime())
+				}
+				ci, ok := info.Sys().(*CommitInfo)
+				if !ok {
+					t.Fatalf("expect Sys() to be *CommitInfo, got: %T", info.Sys())
+				}
+				if ci.Hash != head.Hash {
+					t.Errorf("expect hash: %s, got: %s", head.Hash, ci.Hash)
+				}
+			})
+
+			t.Run("b.txt was last touched by the commit that added it", func(t *testing.T) {
+				info, err := fs.Stat(gfs, "b.txt")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !info.ModTime().Equal(t1) {
+					t.Errorf("expect mod time: %v, got: %v", t1, info.ModTime())
+				}
+			})
+
+			t.Run("ReadDir also reports ModTime", func(t *testing.T) {
+				entries, err := fs.ReadDir(gfs, ".")
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, e := range entries {
+					info, err := e.Info()
+					if err != nil {
+						t.Fatal(err)
+					}
+					if info.ModTime().IsZero() {
+						t.Errorf("expect non-zero mod time for %s", e.Name())
+					}
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package gitfs
+
+import (
+	"io/fs"
+
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// NewIndexed returns a readonly fs.FS like New, but eagerly walks tree
+// once and builds a path-indexed cache covering every file and
+// directory in it. Subsequent Open, Stat, ReadDir and Sub calls resolve
+// against that cache in O(1) instead of re-walking the tree from the
+// root, which matters for callers that make many such calls against a
+// large tree. Sub returns a view that shares the same cache rather than
+// rebuilding it.
+//
+// The tradeoff is upfront time and memory proportional to the size of
+// tree, paid in full even if only a few paths end up being read. For
+// filesystems that only serve a handful of requests, the lazy New is
+// usually the better choice.
+//
+// Submodule gitlink entries are indexed but not resolved eagerly; they
+// surface the same way they do under New with no resolver, as an empty
+// directory carrying a *SubmoduleInfo.
+func NewIndexed(tree *object.Tree) (fs.FS, error) {
+	index, err := buildIndex(tree)
+	if err != nil {
+		return nil, err
+	}
+	return &gitFS{tree: tree, index: index}, nil
+}
+
+// indexEntry is what buildIndex records for a single path.
+type indexEntry struct {
+	// parent is the tree directly containing entry; see walkResult.
+	parent *object.Tree
+	entry  *object.TreeEntry
+	// dirTree mirrors walkResult.dirTree: set for the root and for
+	// Dir entries, nil for files, symlinks and submodules.
+	dirTree *object.Tree
+	// sub is set for a submodule gitlink entry; see walkResult.
+	sub *SubmoduleInfo
+}
+
+// treeIndex is the path -> indexEntry cache built by buildIndex. Paths
+// are stored exactly as fs.FS expects them, with "." denoting the
+// indexed tree's own root.
+type treeIndex struct {
+	entries map[string]*indexEntry
+}
+
+// buildIndex walks tree once, recording every path it contains.
+func buildIndex(tree *object.Tree) (*treeIndex, error) {
+	modules, err := readGitModules(tree)
+	if err != nil {
+		modules = nil
+	}
+
+	index := &treeIndex{entries: make(map[string]*indexEntry)}
+	index.entries["."] = &indexEntry{
+		parent:  tree,
+		entry:   &object.TreeEntry{Name: ".", Mode: filemode.Dir, Hash: tree.Hash},
+		dirTree: tree,
+	}
+	if err := indexTree(index, "", tree, modules); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+func indexTree(index *treeIndex, prefix string, tree *object.Tree, modules *config.Modules) error {
+	for i := range tree.Entries {
+		entry := &tree.Entries[i]
+		path := entry.Name
+		if prefix != "" {
+			path = prefix + "/" + entry.Name
+		}
+
+		ie := &indexEntry{parent: tree, entry: entry}
+		switch entry.Mode {
+		case filemode.Dir:
+			dirTree, err := tree.Tree(entry.Name)
+			if err != nil {
+				return err
+			}
+			ie.dirTree = dirTree
+			index.entries[path] = ie
+			if err := indexTree(index, path, dirTree, modules); err != nil {
+				return err
+			}
+			continue
+		case filemode.Submodule:
+			url := ""
+			if sub := submoduleByPath(modules, path); sub != nil {
+				url = sub.URL
+			}
+			ie.sub = &SubmoduleInfo{Hash: entry.Hash, URL: url}
+		}
+		index.entries[path] = ie
+	}
+	return nil
+}
+
+// indexWalk is walk's counterpart when g.index is set.
+func (g *gitFS) indexWalk(name string) (walkResult, error) {
+	ie, ok := g.index.entries[g.fullPath(name)]
+	if !ok {
+		return walkResult{}, object.ErrEntryNotFound
+	}
+
+	entry := ie.entry
+	if entry.Mode == filemode.Submodule {
+		entry = &object.TreeEntry{Name: entry.Name, Mode: filemode.Dir, Hash: entry.Hash}
+	}
+	return walkResult{parent: ie.parent, entry: entry, dirTree: ie.dirTree, sub: ie.sub}, nil
+}
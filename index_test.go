@@ -0,0 +1,184 @@
+package gitfs
+
+import (
+	"fmt"
+	"io/fs"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// buildWideTree builds a synthetic tree with dirs directories, each
+// holding filesPerDir files, for exercising NewIndexed against a tree
+// too large to eyeball.
+func buildWideTree(t testing.TB, dirs, filesPerDir int) *object.Tree {
+	s := memory.NewStorage()
+
+	root := make([]object.TreeEntry, 0, dirs)
+	for d := 0; d < dirs; d++ {
+		entries := make([]object.TreeEntry, 0, filesPerDir)
+		for f := 0; f < filesPerDir; f++ {
+			hash := storeBlob(t, s, fmt.Sprintf("dir%d/file%d", d, f))
+			entries = append(entries, object.TreeEntry{
+				Name: fmt.Sprintf("file%d.txt", f),
+				Mode: filemode.Regular,
+				Hash: hash,
+			})
+		}
+		sub := storeTree(t, s, entries)
+		root = append(root, object.TreeEntry{
+			Name: fmt.Sprintf("dir%d", d),
+			Mode: filemode.Dir,
+			Hash: sub.Hash,
+		})
+	}
+	return storeTree(t, s, root)
+}
+
+func TestNewIndexed(t *testing.T) {
+	tree := buildWideTree(t, 4, 4)
+
+	t.Run("Open and ReadFile", func(t *testing.T) {
+		gfs, err := NewIndexed(tree)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := fs.ReadFile(gfs, "dir2/file3.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "dir2/file3" {
+			t.Errorf("expect: %s, got: %s", "dir2/file3", string(data))
+		}
+	})
+
+	t.Run("Stat root", func(t *testing.T) {
+		gfs, err := NewIndexed(tree)
+		if err != nil {
+			t.Fatal(err)
+		}
+		info, err := fs.Stat(gfs, ".")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !info.IsDir() {
+			t.Error("expect root to be a directory")
+		}
+	})
+
+	t.Run("ReadDir", func(t *testing.T) {
+		gfs, err := NewIndexed(tree)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries, err := fs.ReadDir(gfs, "dir1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 4 {
+			t.Errorf("expect 4 entries, got: %d", len(entries))
+		}
+	})
+
+	t.Run("Sub shares the index", func(t *testing.T) {
+		gfs, err := NewIndexed(tree)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sub, err := fs.Sub(gfs, "dir1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sub.(*gitFS).index != gfs.(*gitFS).index {
+			t.Error("expect Sub to share the same index")
+		}
+
+		data, err := fs.ReadFile(sub, "file2.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "dir1/file2" {
+			t.Errorf("expect: %s, got: %s", "dir1/file2", string(data))
+		}
+
+		entries, err := fs.ReadDir(sub, ".")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 4 {
+			t.Errorf("expect 4 entries, got: %d", len(entries))
+		}
+	})
+
+	t.Run("file not found", func(t *testing.T) {
+		gfs, err := NewIndexed(tree)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fs.Stat(gfs, "dir1/nope.txt"); err == nil {
+			t.Error("expect an error for a missing path")
+		}
+	})
+}
+
+func TestNewIndexed_Submodule(t *testing.T) {
+	parent, subCommit, _ := newSubmoduleTestTree(t)
+
+	gfs, err := NewIndexed(parent)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := fs.Stat(gfs, "vendor/lib")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.IsDir() {
+		t.Error("expect submodule entry to read as a directory")
+	}
+	sub, ok := info.Sys().(*SubmoduleInfo)
+	if !ok {
+		t.Fatalf("expect Sys() to be *SubmoduleInfo, got: %T", info.Sys())
+	}
+	if sub.Hash != subCommit.Hash {
+		t.Errorf("expect hash: %s, got: %s", subCommit.Hash, sub.Hash)
+	}
+	if sub.URL != "https://example.com/lib.git" {
+		t.Errorf("expect URL: %s, got: %s", "https://example.com/lib.git", sub.URL)
+	}
+}
+
+func BenchmarkOpen(b *testing.B) {
+	tree := buildWideTree(b, 50, 50)
+	paths := make([]string, 0, 50*50)
+	for d := 0; d < 50; d++ {
+		for f := 0; f < 50; f++ {
+			paths = append(paths, fmt.Sprintf("dir%d/file%d.txt", d, f))
+		}
+	}
+
+	b.Run("lazy", func(b *testing.B) {
+		gfs := New(tree)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := fs.Stat(gfs, paths[i%len(paths)]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("indexed", func(b *testing.B) {
+		gfs, err := NewIndexed(tree)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := fs.Stat(gfs, paths[i%len(paths)]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,513 @@
+package gitfs
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// ChangeAction describes how a path differs between an OverlayFS and its
+// base filesystem.
+type ChangeAction int
+
+const (
+	ChangeAdd ChangeAction = iota
+	ChangeModify
+	ChangeDelete
+)
+
+func (a ChangeAction) String() string {
+	switch a {
+	case ChangeAdd:
+		return "add"
+	case ChangeModify:
+		return "modify"
+	case ChangeDelete:
+		return "delete"
+	default:
+		return "unknown"
+	}
+}
+
+// Change describes a single path that an OverlayFS adds, modifies or
+// deletes relative to its base filesystem.
+type Change struct {
+	Path   string
+	Action ChangeAction
+}
+
+// OverlayFS is a writable copy-on-write layer on top of a readonly base
+// fs.FS, inspired by afero's CopyOnWriteFs. Reads fall through to base
+// unless a path has been shadowed by a pending write, an explicitly
+// created directory, or a tombstone recording a deletion; writes never
+// mutate base. Call Commit to materialise the accumulated changes into
+// a new object.Tree in storer.
+type OverlayFS struct {
+	base   fs.FS
+	storer storer.EncodedObjectStorer
+
+	writes     map[string][]byte
+	dirs       map[string]bool
+	tombstones map[string]bool
+}
+
+// NewOverlay returns a writable OverlayFS layered on top of base. Blobs
+// and trees produced by Commit are written to storer.
+func NewOverlay(base fs.FS, storer storer.EncodedObjectStorer) *OverlayFS {
+	return &OverlayFS{
+		base:       base,
+		storer:     storer,
+		writes:     make(map[string][]byte),
+		dirs:       make(map[string]bool),
+		tombstones: make(map[string]bool),
+	}
+}
+
+// Open opens the named file, preferring the overlay's pending writes
+// over the base filesystem.
+func (o *OverlayFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, toFSError("open", name, fs.ErrInvalid)
+	}
+	if o.isTombstoned(name) {
+		return nil, toFSError("open", name, fs.ErrNotExist)
+	}
+	if content, ok := o.writes[name]; ok {
+		return newOverlayFile(name, content), nil
+	}
+
+	isDir, err := o.isDir(name)
+	if err != nil {
+		return nil, toFSError("open", name, err)
+	}
+	if isDir {
+		return newOverlayDir(name), nil
+	}
+	return o.base.Open(name)
+}
+
+// ReadDir reads the named directory, merging entries pending in the
+// overlay with those of the base filesystem.
+func (o *OverlayFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, toFSError("readdir", name, fs.ErrInvalid)
+	}
+	if o.isTombstoned(name) {
+		return nil, toFSError("readdir", name, fs.ErrNotExist)
+	}
+
+	names, err := o.children(name)
+	if err != nil {
+		return nil, toFSError("readdir", name, err)
+	}
+
+	entries := make([]fs.DirEntry, 0, len(names))
+	for _, n := range names {
+		info, err := fs.Stat(o, path.Join(name, n))
+		if err != nil {
+			return nil, toFSError("readdir", name, err)
+		}
+		entries = append(entries, fs.FileInfoToDirEntry(info))
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+// Create opens name for writing, creating it if needed, and returns a
+// handle whose Close commits the written bytes to the overlay. The
+// write is never visible to base; it only shadows it.
+func (o *OverlayFS) Create(name string) (io.WriteCloser, error) {
+	if !fs.ValidPath(name) {
+		return nil, toFSError("create", name, fs.ErrInvalid)
+	}
+	delete(o.dirs, name)
+	delete(o.tombstones, name)
+	return &overlayWriter{o: o, name: name}, nil
+}
+
+// Remove shadows name with a tombstone, so that it reads as deleted
+// regardless of what base contains.
+func (o *OverlayFS) Remove(name string) error {
+	if !fs.ValidPath(name) {
+		return toFSError("remove", name, fs.ErrInvalid)
+	}
+	if _, err := fs.Stat(o, name); err != nil {
+		return toFSError("remove", name, err)
+	}
+	o.purgePrefix(name)
+	o.tombstones[name] = true
+	return nil
+}
+
+// Mkdir creates name as an empty directory in the overlay. perm is
+// accepted for parity with os.Mkdir but is not preserved:
+// git trees have no directory permission bits of their own.
+func (o *OverlayFS) Mkdir(name string, perm fs.FileMode) error {
+	if !fs.ValidPath(name) {
+		return toFSError("mkdir", name, fs.ErrInvalid)
+	}
+	if _, err := fs.Stat(o, name); err == nil {
+		return toFSError("mkdir", name, fs.ErrExist)
+	}
+	o.dirs[name] = true
+	delete(o.tombstones, name)
+	return nil
+}
+
+// Rename moves old to new within the overlay. The source is tombstoned
+// and its content, and that of any descendants if it is a directory, is
+// copied to the destination as pending writes.
+func (o *OverlayFS) Rename(old, new string) error {
+	if !fs.ValidPath(old) {
+		return toFSError("rename", old, fs.ErrInvalid)
+	}
+	if !fs.ValidPath(new) {
+		return toFSError("rename", new, fs.ErrInvalid)
+	}
+
+	isDir, err := o.isDir(old)
+	if err != nil {
+		return toFSError("rename", old, err)
+	}
+
+	if isDir {
+		err = fs.WalkDir(o, old, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			content, err := fs.ReadFile(o, p)
+			if err != nil {
+				return err
+			}
+			o.writes[path.Join(new, p[len(old):])] = content
+			return nil
+		})
+		if err != nil {
+			return toFSError("rename", old, err)
+		}
+	} else {
+		content, err := fs.ReadFile(o, old)
+		if err != nil {
+			return toFSError("rename", old, err)
+		}
+		o.writes[new] = content
+		delete(o.dirs, new)
+	}
+	delete(o.tombstones, new)
+
+	o.tombstones[old] = true
+	o.purgePrefix(old)
+	return nil
+}
+
+// purgePrefix removes dir itself, and every overlay-local write or
+// directory entry nested under it, from o.writes and o.dirs. It is used
+// after a rename so that stale entries for the old location don't
+// linger and surface as ghost changes in Diff.
+func (o *OverlayFS) purgePrefix(dir string) {
+	delete(o.writes, dir)
+	delete(o.dirs, dir)
+
+	prefix := dir + "/"
+	for p := range o.writes {
+		if strings.HasPrefix(p, prefix) {
+			delete(o.writes, p)
+		}
+	}
+	for p := range o.dirs {
+		if strings.HasPrefix(p, prefix) {
+			delete(o.dirs, p)
+		}
+	}
+}
+
+// Diff reports the paths the overlay adds, modifies or deletes relative
+// to base, suitable for driving git.Worktree-style status output.
+func (o *OverlayFS) Diff() ([]Change, error) {
+	var changes []Change
+	for p := range o.tombstones {
+		if _, err := fs.Stat(o.base, p); err == nil {
+			changes = append(changes, Change{Path: p, Action: ChangeDelete})
+		}
+	}
+	for p := range o.writes {
+		action := ChangeAdd
+		if _, err := fs.Stat(o.base, p); err == nil {
+			action = ChangeModify
+		}
+		changes = append(changes, Change{Path: p, Action: action})
+	}
+	for p := range o.dirs {
+		if _, err := fs.Stat(o.base, p); err != nil {
+			changes = append(changes, Change{Path: p, Action: ChangeAdd})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+// Commit materialises the overlay into a new tree in storer, folding
+// pending writes and tombstones with the untouched entries read through
+// from base, and returns the hash of the resulting object.Tree.
+func (o *OverlayFS) Commit() (plumbing.Hash, error) {
+	return o.commitDir(".")
+}
+
+func (o *OverlayFS) commitDir(dir string) (plumbing.Hash, error) {
+	names, err := o.children(dir)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	entries := make([]object.TreeEntry, 0, len(names))
+	for _, name := range names {
+		full := path.Join(dir, name)
+
+		isDir, err := o.isDir(full)
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+		if isDir {
+			hash, err := o.commitDir(full)
+			if err != nil {
+				return plumbing.ZeroHash, err
+			}
+			entries = append(entries, object.TreeEntry{Name: name, Mode: filemode.Dir, Hash: hash})
+			continue
+		}
+
+		mode, err := o.fileMode(full)
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+		content, err := fs.ReadFile(o, full)
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+		hash, err := o.storeBlob(content)
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+		entries = append(entries, object.TreeEntry{Name: name, Mode: mode, Hash: hash})
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return treeEntryLess(entries[i], entries[j]) })
+	return o.storeTree(entries)
+}
+
+// treeEntryLess orders tree entries the way git requires: as if
+// directory names had a trailing "/" appended.
+func treeEntryLess(a, b object.TreeEntry) bool {
+	an, bn := a.Name, b.Name
+	if a.Mode == filemode.Dir {
+		an += "/"
+	}
+	if b.Mode == filemode.Dir {
+		bn += "/"
+	}
+	return an < bn
+}
+
+func (o *OverlayFS) fileMode(full string) (filemode.FileMode, error) {
+	if _, ok := o.writes[full]; ok {
+		return filemode.Regular, nil
+	}
+	info, err := fs.Stat(o.base, full)
+	if err != nil {
+		return filemode.Empty, err
+	}
+	return filemode.NewFromOSFileMode(info.Mode())
+}
+
+func (o *OverlayFS) storeBlob(content []byte) (plumbing.Hash, error) {
+	obj := o.storer.NewEncodedObject()
+	obj.SetType(plumbing.BlobObject)
+	w, err := obj.Writer()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	if _, err := w.Write(content); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	if err := w.Close(); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return o.storer.SetEncodedObject(obj)
+}
+
+func (o *OverlayFS) storeTree(entries []object.TreeEntry) (plumbing.Hash, error) {
+	tree := &object.Tree{Entries: entries}
+	obj := o.storer.NewEncodedObject()
+	if err := tree.Encode(obj); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return o.storer.SetEncodedObject(obj)
+}
+
+// isTombstoned reports whether name, or any of its ancestor
+// directories, has been removed in the overlay.
+func (o *OverlayFS) isTombstoned(name string) bool {
+	if o.tombstones[name] {
+		return true
+	}
+	for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+		if o.tombstones[dir] {
+			return true
+		}
+	}
+	return false
+}
+
+// isDir reports whether name denotes a directory, considering
+// explicitly created directories, directories implied by a deeper
+// pending write or Mkdir, and directories inherited from base.
+func (o *OverlayFS) isDir(name string) (bool, error) {
+	if o.dirs[name] {
+		return true, nil
+	}
+	if _, ok := o.writes[name]; ok {
+		return false, nil
+	}
+
+	prefix := name + "/"
+	if name == "." {
+		prefix = ""
+	}
+	for p := range o.writes {
+		if p != name && strings.HasPrefix(p, prefix) {
+			return true, nil
+		}
+	}
+	for p := range o.dirs {
+		if p != name && strings.HasPrefix(p, prefix) {
+			return true, nil
+		}
+	}
+
+	info, err := fs.Stat(o.base, name)
+	if err != nil {
+		return false, err
+	}
+	return info.IsDir(), nil
+}
+
+// children returns the direct child names of dir, merging base entries
+// that survive tombstones with overlay-introduced files and
+// directories, including those implied by a deeper pending write.
+func (o *OverlayFS) children(dir string) ([]string, error) {
+	set := make(map[string]bool)
+
+	if entries, err := fs.ReadDir(o.base, dir); err == nil {
+		for _, e := range entries {
+			full := path.Join(dir, e.Name())
+			if !o.isTombstoned(full) {
+				set[e.Name()] = true
+			}
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	prefix := dir + "/"
+	if dir == "." {
+		prefix = ""
+	}
+	for p := range o.writes {
+		if name, ok := directChild(p, prefix); ok && !o.isTombstoned(path.Join(dir, name)) {
+			set[name] = true
+		}
+	}
+	for p := range o.dirs {
+		if name, ok := directChild(p, prefix); ok && !o.isTombstoned(path.Join(dir, name)) {
+			set[name] = true
+		}
+	}
+
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// directChild reports whether p is prefix followed by at least one more
+// path component, returning that component's name. It is used to
+// derive the direct children of dir from the flat set of overlay paths,
+// including implied intermediate directories.
+func directChild(p, prefix string) (string, bool) {
+	if !strings.HasPrefix(p, prefix) {
+		return "", false
+	}
+	rest := p[len(prefix):]
+	if rest == "" {
+		return "", false
+	}
+	if i := strings.IndexByte(rest, '/'); i >= 0 {
+		return rest[:i], true
+	}
+	return rest, true
+}
+
+type overlayWriter struct {
+	o    *OverlayFS
+	name string
+	buf  bytes.Buffer
+}
+
+func (w *overlayWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+func (w *overlayWriter) Close() error {
+	w.o.writes[w.name] = append([]byte(nil), w.buf.Bytes()...)
+	delete(w.o.tombstones, w.name)
+	return nil
+}
+
+type overlayFile struct {
+	name    string
+	content []byte
+	r       *bytes.Reader
+}
+
+func newOverlayFile(name string, content []byte) *overlayFile {
+	return &overlayFile{name: path.Base(name), content: content, r: bytes.NewReader(content)}
+}
+
+func (f *overlayFile) Stat() (fs.FileInfo, error) { return f, nil }
+func (f *overlayFile) Read(p []byte) (int, error) { return f.r.Read(p) }
+func (f *overlayFile) Close() error               { return nil }
+func (f *overlayFile) Name() string               { return f.name }
+func (f *overlayFile) Size() int64                { return int64(len(f.content)) }
+func (f *overlayFile) Mode() fs.FileMode          { return fs.FileMode(0644) }
+func (f *overlayFile) ModTime() time.Time         { return time.Time{} }
+func (f *overlayFile) IsDir() bool                { return false }
+func (f *overlayFile) Sys() interface{}           { return nil }
+
+type overlayDir struct {
+	name string
+}
+
+func newOverlayDir(name string) *overlayDir {
+	return &overlayDir{name: path.Base(name)}
+}
+
+func (d *overlayDir) Stat() (fs.FileInfo, error) { return d, nil }
+func (d *overlayDir) Read([]byte) (int, error)   { return 0, io.EOF }
+func (d *overlayDir) Close() error               { return nil }
+func (d *overlayDir) Name() string               { return d.name }
+func (d *overlayDir) Size() int64                { return 0 }
+func (d *overlayDir) Mode() fs.FileMode          { return fs.ModeDir | 0755 }
+func (d *overlayDir) ModTime() time.Time         { return time.Time{} }
+func (d *overlayDir) IsDir() bool                { return true }
+func (d *overlayDir) Sys() interface{}           { return nil }
@@ -0,0 +1,287 @@
+package gitfs
+
+import (
+	"errors"
+	"io/fs"
+	"sort"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+func newOverlayTestTree(t *testing.T) *object.Tree {
+	s := memory.NewStorage()
+	readme := storeBlob(t, s, "# README")
+	dir12txt := storeBlob(t, s, "dir12")
+	dir12 := storeTree(t, s, []object.TreeEntry{
+		{Name: "dir12.txt", Mode: filemode.Regular, Hash: dir12txt},
+	})
+	return storeTree(t, s, []object.TreeEntry{
+		{Name: "README.md", Mode: filemode.Regular, Hash: readme},
+		{Name: "dir1", Mode: filemode.Dir, Hash: dir12.Hash},
+	})
+}
+
+func TestOverlayFS(t *testing.T) {
+	t.Run("reads fall through to base", func(t *testing.T) {
+		tree := newOverlayTestTree(t)
+		o := NewOverlay(New(tree), memory.NewStorage())
+
+		data, err := fs.ReadFile(o, "README.md")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "# README" {
+			t.Errorf("expect: %s, got: %s", "# README", string(data))
+		}
+	})
+
+	t.Run("writes shadow base without mutating it", func(t *testing.T) {
+		tree := newOverlayTestTree(t)
+		o := NewOverlay(New(tree), memory.NewStorage())
+
+		w, err := o.Create("README.md")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte("# NEW README")); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := fs.ReadFile(o, "README.md")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "# NEW README" {
+			t.Errorf("expect: %s, got: %s", "# NEW README", string(data))
+		}
+
+		baseData, err := fs.ReadFile(New(tree), "README.md")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(baseData) != "# README" {
+			t.Errorf("expect base untouched: %s, got: %s", "# README", string(baseData))
+		}
+	})
+
+	t.Run("Remove tombstones a path", func(t *testing.T) {
+		tree := newOverlayTestTree(t)
+		o := NewOverlay(New(tree), memory.NewStorage())
+
+		if err := o.Remove("README.md"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fs.Stat(o, "README.md"); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("expect ErrNotExist, got: %v", err)
+		}
+	})
+
+	t.Run("Remove of a directory leaves no stale entries in Diff", func(t *testing.T) {
+		tree := newOverlayTestTree(t)
+		o := NewOverlay(New(tree), memory.NewStorage())
+
+		if err := o.Mkdir("newdir", 0755); err != nil {
+			t.Fatal(err)
+		}
+		w, err := o.Create("newdir/a.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte("a"))
+		w.Close()
+
+		if err := o.Remove("newdir"); err != nil {
+			t.Fatal(err)
+		}
+
+		changes, err := o.Diff()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(changes) != 0 {
+			t.Errorf("expect no changes, got: %v", changes)
+		}
+	})
+
+	t.Run("Mkdir and new file are visible in ReadDir", func(t *testing.T) {
+		tree := newOverlayTestTree(t)
+		o := NewOverlay(New(tree), memory.NewStorage())
+
+		if err := o.Mkdir("newdir", 0755); err != nil {
+			t.Fatal(err)
+		}
+		w, err := o.Create("newdir/new.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte("new"))
+		w.Close()
+
+		entries, err := fs.ReadDir(o, ".")
+		if err != nil {
+			t.Fatal(err)
+		}
+		names := entryNames(entries)
+		if !contains(names, "newdir") || !contains(names, "README.md") || !contains(names, "dir1") {
+			t.Errorf("expect newdir, README.md, dir1 in %v", names)
+		}
+
+		sub, err := fs.ReadDir(o, "newdir")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(sub) != 1 || sub[0].Name() != "new.txt" {
+			t.Errorf("expect single entry new.txt, got: %v", sub)
+		}
+	})
+
+	t.Run("Rename moves a file", func(t *testing.T) {
+		tree := newOverlayTestTree(t)
+		o := NewOverlay(New(tree), memory.NewStorage())
+
+		if err := o.Rename("README.md", "RENAMED.md"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fs.Stat(o, "README.md"); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("expect source gone, got: %v", err)
+		}
+		data, err := fs.ReadFile(o, "RENAMED.md")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "# README" {
+			t.Errorf("expect: %s, got: %s", "# README", string(data))
+		}
+	})
+
+	t.Run("Rename of a directory leaves no stale entries in Diff", func(t *testing.T) {
+		tree := newOverlayTestTree(t)
+		o := NewOverlay(New(tree), memory.NewStorage())
+
+		if err := o.Mkdir("dir", 0755); err != nil {
+			t.Fatal(err)
+		}
+		w, err := o.Create("dir/a.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte("a"))
+		w.Close()
+
+		if err := o.Rename("dir", "dir2"); err != nil {
+			t.Fatal(err)
+		}
+
+		changes, err := o.Diff()
+		if err != nil {
+			t.Fatal(err)
+		}
+		sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+		want := []Change{
+			{Path: "dir2/a.txt", Action: ChangeAdd},
+		}
+		if len(changes) != len(want) {
+			t.Fatalf("expect %d changes, got %d: %v", len(want), len(changes), changes)
+		}
+		for i, c := range changes {
+			if c != want[i] {
+				t.Errorf("expect %v, got %v", want[i], c)
+			}
+		}
+	})
+
+	t.Run("Diff reports add, modify and delete", func(t *testing.T) {
+		tree := newOverlayTestTree(t)
+		o := NewOverlay(New(tree), memory.NewStorage())
+
+		w, _ := o.Create("README.md")
+		w.Write([]byte("modified"))
+		w.Close()
+
+		w, _ = o.Create("NEW.md")
+		w.Write([]byte("new"))
+		w.Close()
+
+		o.Remove("dir1/dir12.txt")
+
+		changes, err := o.Diff()
+		if err != nil {
+			t.Fatal(err)
+		}
+		sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+		want := []Change{
+			{Path: "NEW.md", Action: ChangeAdd},
+			{Path: "README.md", Action: ChangeModify},
+			{Path: "dir1/dir12.txt", Action: ChangeDelete},
+		}
+		if len(changes) != len(want) {
+			t.Fatalf("expect %d changes, got %d: %v", len(want), len(changes), changes)
+		}
+		for i, c := range changes {
+			if c != want[i] {
+				t.Errorf("expect %v, got %v", want[i], c)
+			}
+		}
+	})
+
+	t.Run("Commit materialises a new tree", func(t *testing.T) {
+		tree := newOverlayTestTree(t)
+		storer := memory.NewStorage()
+		o := NewOverlay(New(tree), storer)
+
+		w, _ := o.Create("README.md")
+		w.Write([]byte("# NEW README"))
+		w.Close()
+		o.Remove("dir1/dir12.txt")
+
+		hash, err := o.Commit()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		newTree, err := object.GetTree(storer, hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		newFS := New(newTree)
+
+		data, err := fs.ReadFile(newFS, "README.md")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "# NEW README" {
+			t.Errorf("expect: %s, got: %s", "# NEW README", string(data))
+		}
+
+		entries, err := fs.ReadDir(newFS, "dir1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 0 {
+			t.Errorf("expect dir1 to be empty after deleting its only file, got: %v", entries)
+		}
+	})
+}
+
+func entryNames(entries []fs.DirEntry) []string {
+	names := make([]string, len(entries))
+	for i, e := range entries {
+		names[i] = e.Name()
+	}
+	return names
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package gitfs
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+func TestGitFile_Seek(t *testing.T) {
+	s := memory.NewStorage()
+	content := "the quick brown fox jumps over the lazy dog"
+	blob := storeBlob(t, s, content)
+	tree := storeTree(t, s, []object.TreeEntry{
+		{Name: "fox.txt", Mode: filemode.Regular, Hash: blob},
+	})
+
+	t.Run("Seek and Read", func(t *testing.T) {
+		gfs := New(tree)
+		f, err := gfs.Open("fox.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		seeker, ok := f.(io.Seeker)
+		if !ok {
+			t.Fatal("expect file to implement io.Seeker")
+		}
+		if _, err := seeker.Seek(4, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != content[4:] {
+			t.Errorf("expect: %q, got: %q", content[4:], string(data))
+		}
+	})
+
+	t.Run("ReadAt", func(t *testing.T) {
+		gfs := New(tree)
+		f, err := gfs.Open("fox.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		readerAt, ok := f.(io.ReaderAt)
+		if !ok {
+			t.Fatal("expect file to implement io.ReaderAt")
+		}
+		buf := make([]byte, 5)
+		if _, err := readerAt.ReadAt(buf, 16); err != nil {
+			t.Fatal(err)
+		}
+		if string(buf) != content[16:21] {
+			t.Errorf("expect: %q, got: %q", content[16:21], string(buf))
+		}
+	})
+
+	t.Run("ReadSeeker works with http.ServeContent-style random access", func(t *testing.T) {
+		gfs := New(tree)
+		f, err := gfs.Open("fox.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		rs, ok := f.(*gitFile)
+		if !ok {
+			t.Fatal("expect *gitFile")
+		}
+		rsk, err := rs.ReadSeeker()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := rsk.Seek(-3, io.SeekEnd); err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(rsk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != content[len(content)-3:] {
+			t.Errorf("expect: %q, got: %q", content[len(content)-3:], string(data))
+		}
+	})
+
+	t.Run("Seek on a directory fails", func(t *testing.T) {
+		dirTree := storeTree(t, s, []object.TreeEntry{
+			{Name: "sub", Mode: filemode.Dir, Hash: tree.Hash},
+		})
+		gfs := New(dirTree)
+		f, err := gfs.Open("sub")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		seeker := f.(io.Seeker)
+		if _, err := seeker.Seek(0, io.SeekStart); !errors.Is(err, fs.ErrInvalid) {
+			t.Errorf("expect fs.ErrInvalid, got: %v", err)
+		}
+	})
+
+	t.Run("spills to disk past the configured threshold", func(t *testing.T) {
+		gfs := NewWithOptions(tree, Options{SpillToDiskThreshold: 1})
+		f, err := gfs.Open("fox.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		rs := f.(*gitFile)
+		rsk, err := rs.ReadSeeker()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := rsk.(*bytes.Reader); ok {
+			t.Error("expect spilled content not to be a *bytes.Reader")
+		}
+		data, err := io.ReadAll(rsk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != content {
+			t.Errorf("expect: %q, got: %q", content, string(data))
+		}
+		if err := f.Close(); err != nil {
+			t.Errorf("expect spilled file to close cleanly, got: %v", err)
+		}
+	})
+}
@@ -0,0 +1,139 @@
+package gitfs
+
+import (
+	"fmt"
+	"io/fs"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// SubmoduleResolver resolves the tree pointed at by a submodule (gitlink)
+// entry found at path, whose commit in the submodule's own repository is
+// hash. A resolver that cannot resolve a given submodule should return a
+// non-nil error; the entry then surfaces as an empty directory, see
+// SubmoduleInfo.
+type SubmoduleResolver func(path string, hash plumbing.Hash) (*object.Tree, error)
+
+// SubmoduleInfo is returned by FileInfo.Sys for a submodule entry that
+// could not be resolved into a tree, either because NewWithResolver was
+// not used or because the configured SubmoduleResolver failed.
+type SubmoduleInfo struct {
+	// URL is the submodule's URL as declared in .gitmodules, or empty
+	// if it could not be determined.
+	URL string
+	// Hash is the commit the submodule is pinned to.
+	Hash plumbing.Hash
+}
+
+// NewWithResolver returns a readonly fs.FS filesystem based on
+// object.Tree in which submodule (gitlink) entries are resolved via
+// resolver and spliced into the filesystem as subdirectories.
+func NewWithResolver(tree *object.Tree, resolver SubmoduleResolver) fs.FS {
+	return &gitFS{tree: tree, resolver: resolver}
+}
+
+// NewGitModulesResolver returns a SubmoduleResolver that looks up the
+// submodule mounted at a given path in the .gitmodules file of
+// parentTree, and resolves its pinned commit into a tree using either
+// repos, keyed by the submodule's path, or, for submodules not present
+// in repos, a shared object storer such as a combined alternates store.
+// Either repos or fallback may be nil.
+func NewGitModulesResolver(parentTree *object.Tree, repos map[string]*git.Repository, fallback storer.EncodedObjectStorer) (SubmoduleResolver, error) {
+	modules, err := readGitModules(parentTree)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(path string, hash plumbing.Hash) (*object.Tree, error) {
+		sub := submoduleByPath(modules, path)
+		if sub == nil {
+			return nil, fmt.Errorf("gitfs: %q is not declared in .gitmodules", path)
+		}
+
+		var es storer.EncodedObjectStorer
+		switch {
+		case repos[path] != nil:
+			es = repos[path].Storer
+		case fallback != nil:
+			es = fallback
+		default:
+			return nil, fmt.Errorf("gitfs: no repository registered for submodule %q", path)
+		}
+
+		commit, err := object.GetCommit(es, hash)
+		if err != nil {
+			return nil, err
+		}
+		return commit.Tree()
+	}, nil
+}
+
+// resolveSubmodule resolves the submodule gitlink at path, pinned to
+// hash, using g.resolver. It reports ok=false if there is no resolver
+// or the resolver failed, in which case the caller should surface the
+// submodule as an empty directory instead of failing the whole walk.
+func (g *gitFS) resolveSubmodule(path string, hash plumbing.Hash) (tree *object.Tree, ok bool) {
+	if g.resolver == nil {
+		return nil, false
+	}
+	tree, err := g.resolver(path, hash)
+	if err != nil {
+		return nil, false
+	}
+	return tree, true
+}
+
+// submoduleURL returns the URL declared for the submodule mounted at
+// path in the tree's .gitmodules file, loading and caching it on first
+// use. It returns "" if there is no .gitmodules file, it cannot be
+// parsed, or it has no entry for path.
+func (g *gitFS) submoduleURL(path string) string {
+	if !g.modulesLoaded {
+		g.modulesLoaded = true
+		g.modules, _ = readGitModules(g.tree)
+	}
+	if sub := submoduleByPath(g.modules, path); sub != nil {
+		return sub.URL
+	}
+	return ""
+}
+
+// readGitModules reads and parses the .gitmodules file at the root of
+// tree, if any.
+func readGitModules(tree *object.Tree) (*config.Modules, error) {
+	file, err := tree.File(".gitmodules")
+	if err != nil {
+		if err == object.ErrFileNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	content, err := file.Contents()
+	if err != nil {
+		return nil, err
+	}
+
+	modules := config.NewModules()
+	if err := modules.Unmarshal([]byte(content)); err != nil {
+		return nil, err
+	}
+	return modules, nil
+}
+
+// submoduleByPath returns the submodule declared in modules whose Path
+// matches path, or nil if modules is nil or has no such entry.
+func submoduleByPath(modules *config.Modules, path string) *config.Submodule {
+	if modules == nil {
+		return nil
+	}
+	for _, sub := range modules.Submodules {
+		if sub.Path == path {
+			return sub
+		}
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package gitfs
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+func storeBlob(t testing.TB, s storer.EncodedObjectStorer, content string) plumbing.Hash {
+	obj := s.NewEncodedObject()
+	obj.SetType(plumbing.BlobObject)
+	w, err := obj.Writer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	h, err := s.SetEncodedObject(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func storeTree(t testing.TB, s storer.EncodedObjectStorer, entries []object.TreeEntry) *object.Tree {
+	tree := &object.Tree{Entries: entries}
+	obj := s.NewEncodedObject()
+	obj.SetType(plumbing.TreeObject)
+	if err := tree.Encode(obj); err != nil {
+		t.Fatal(err)
+	}
+	h, err := s.SetEncodedObject(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := object.GetTree(s, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func storeCommit(t testing.TB, s storer.EncodedObjectStorer, treeHash plumbing.Hash) *object.Commit {
+	commit := &object.Commit{
+		Author: object.Signature{
+			Name: "gitfs", Email: "gitfs@xxx.com", When: time.Now(),
+		},
+		Message:  "submodule commit",
+		TreeHash: treeHash,
+	}
+	obj := s.NewEncodedObject()
+	obj.SetType(plumbing.CommitObject)
+	if err := commit.Encode(obj); err != nil {
+		t.Fatal(err)
+	}
+	h, err := s.SetEncodedObject(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := object.GetCommit(s, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+// newSubmoduleTestTree builds a tree with a "vendor/lib" submodule
+// gitlink entry, a ".gitmodules" file declaring it, and returns the
+// parent tree plus the commit the gitlink is pinned to in its own
+// (separate) storer.
+func newSubmoduleTestTree(t *testing.T) (parent *object.Tree, subCommit *object.Commit, subStorer storer.EncodedObjectStorer) {
+	subStorer = memory.NewStorage()
+	libFile := storeBlob(t, subStorer, "lib contents")
+	libTree := storeTree(t, subStorer, []object.TreeEntry{
+		{Name: "lib.go", Mode: filemode.Regular, Hash: libFile},
+	})
+	subCommit = storeCommit(t, subStorer, libTree.Hash)
+
+	parentStorer := memory.NewStorage()
+	gitmodules := storeBlob(t, parentStorer, "[submodule \"lib\"]\n\tpath = vendor/lib\n\turl = https://example.com/lib.git\n")
+	vendorTree := storeTree(t, parentStorer, []object.TreeEntry{
+		{Name: "lib", Mode: filemode.Submodule, Hash: subCommit.Hash},
+	})
+	readme := storeBlob(t, parentStorer, "# README")
+	parent = storeTree(t, parentStorer, []object.TreeEntry{
+		{Name: ".gitmodules", Mode: filemode.Regular, Hash: gitmodules},
+		{Name: "README.md", Mode: filemode.Regular, Hash: readme},
+		{Name: "vendor", Mode: filemode.Dir, Hash: vendorTree.Hash},
+	})
+	return parent, subCommit, subStorer
+}
+
+func TestGitFS_Submodule(t *testing.T) {
+	parent, subCommit, subStorer := newSubmoduleTestTree(t)
+
+	t.Run("unresolved submodule reads as an empty directory", func(t *testing.T) {
+		gfs := New(parent)
+
+		entries, err := fs.ReadDir(gfs, "vendor/lib")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 0 {
+			t.Errorf("expect empty directory, got: %v", entries)
+		}
+
+		info, err := fs.Stat(gfs, "vendor/lib")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !info.IsDir() {
+			t.Errorf("expect submodule entry to read as a directory")
+		}
+		sub, ok := info.Sys().(*SubmoduleInfo)
+		if !ok {
+			t.Fatalf("expect Sys() to be *SubmoduleInfo, got: %T", info.Sys())
+		}
+		if sub.URL != "https://example.com/lib.git" {
+			t.Errorf("expect URL: %s, got: %s", "https://example.com/lib.git", sub.URL)
+		}
+		if sub.Hash != subCommit.Hash {
+			t.Errorf("expect hash: %s, got: %s", subCommit.Hash, sub.Hash)
+		}
+	})
+
+	t.Run("resolved submodule splices in the pointed-at tree", func(t *testing.T) {
+		resolver := func(path string, hash plumbing.Hash) (*object.Tree, error) {
+			if path != "vendor/lib" || hash != subCommit.Hash {
+				return nil, errors.New("unexpected submodule lookup")
+			}
+			return subCommit.Tree()
+		}
+		gfs := NewWithResolver(parent, resolver)
+
+		data, err := fs.ReadFile(gfs, "vendor/lib/lib.go")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "lib contents" {
+			t.Errorf("expect contents: %s, got: %s", "lib contents", string(data))
+		}
+
+		entries, err := fs.ReadDir(gfs, "vendor/lib")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 || entries[0].Name() != "lib.go" {
+			t.Errorf("expect single entry lib.go, got: %v", entries)
+		}
+	})
+
+	t.Run("NewGitModulesResolver resolves via a fallback storer", func(t *testing.T) {
+		resolver, err := NewGitModulesResolver(parent, nil, subStorer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gfs := NewWithResolver(parent, resolver)
+
+		data, err := fs.ReadFile(gfs, "vendor/lib/lib.go")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "lib contents" {
+			t.Errorf("expect contents: %s, got: %s", "lib contents", string(data))
+		}
+	})
+
+	t.Run("NewGitModulesResolver reports undeclared submodules", func(t *testing.T) {
+		resolver, err := NewGitModulesResolver(parent, nil, subStorer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := resolver("vendor/unknown", subCommit.Hash); err == nil {
+			t.Error("expect error for a path not declared in .gitmodules")
+		}
+	})
+}
@@ -0,0 +1,193 @@
+package gitfs
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+func initSymlinkTestGitRepo(t *testing.T, r *git.Repository, ws string) plumbing.Hash {
+	if err := os.WriteFile(filepath.Join(ws, "target.txt"), []byte("target"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(ws, "script.sh"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("target.txt", filepath.Join(ws, "link.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("/etc/passwd", filepath.Join(ws, "escape.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("../outside.txt", filepath.Join(ws, "traverse.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(ws, "realdir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(ws, "realdir", "file.txt"), []byte("in realdir"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("realdir", filepath.Join(ws, "linkdir")); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := r.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.AddWithOptions(&git.AddOptions{All: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	hash, err := w.Commit("files for gitfs symlink test", &git.CommitOptions{
+		Author: &object.Signature{
+			Name:  "gitfs",
+			Email: "gitfs@xxx.com",
+			When:  time.Now(),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
+
+func newSymlinkTestTree(t *testing.T) *object.Tree {
+	ws := t.TempDir()
+	r, err := git.Init(memory.NewStorage(), osfs.New(ws))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := initSymlinkTestGitRepo(t, r, ws)
+	commit, err := r.CommitObject(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree
+}
+
+func TestGitFS_Symlink(t *testing.T) {
+	tree := newSymlinkTestTree(t)
+	gfs := New(tree)
+
+	t.Run("executable bit survives Mode", func(t *testing.T) {
+		info, err := fs.Stat(gfs, "script.sh")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode() != fs.FileMode(0755) {
+			t.Errorf("expect mode: %v, got: %v", fs.FileMode(0755), info.Mode())
+		}
+	})
+
+	t.Run("Lstat reports the link itself", func(t *testing.T) {
+		lfs, ok := gfs.(interface {
+			Lstat(name string) (fs.FileInfo, error)
+		})
+		if !ok {
+			t.Fatal("gitFS does not implement Lstat")
+		}
+		info, err := lfs.Lstat("link.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Type() != fs.ModeSymlink {
+			t.Errorf("expect mode type: %v, got: %v", fs.ModeSymlink, info.Mode().Type())
+		}
+	})
+
+	t.Run("ReadLink returns the target", func(t *testing.T) {
+		rfs, ok := gfs.(interface {
+			ReadLink(name string) (string, error)
+		})
+		if !ok {
+			t.Fatal("gitFS does not implement ReadLink")
+		}
+		target, err := rfs.ReadLink("link.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if target != "target.txt" {
+			t.Errorf("expect target: %s, got: %s", "target.txt", target)
+		}
+	})
+
+	t.Run("ReadLink on non-symlink", func(t *testing.T) {
+		rfs := gfs.(interface {
+			ReadLink(name string) (string, error)
+		})
+		_, err := rfs.ReadLink("target.txt")
+		if !errors.Is(err, fs.ErrInvalid) {
+			t.Errorf("expect error: %v, got: %v", fs.ErrInvalid, err)
+		}
+	})
+
+	t.Run("Open without FollowSymlinks returns the link, not the target", func(t *testing.T) {
+		data, err := fs.ReadFile(gfs, "link.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "target.txt" {
+			t.Errorf("expect raw link contents: %s, got: %s", "target.txt", string(data))
+		}
+	})
+
+	t.Run("Open with FollowSymlinks resolves the target", func(t *testing.T) {
+		gfs := NewWithOptions(tree, Options{FollowSymlinks: true})
+		data, err := fs.ReadFile(gfs, "link.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "target" {
+			t.Errorf("expect resolved contents: %s, got: %s", "target", string(data))
+		}
+	})
+
+	t.Run("Open with FollowSymlinks rejects absolute targets", func(t *testing.T) {
+		gfs := NewWithOptions(tree, Options{FollowSymlinks: true})
+		_, err := gfs.Open("escape.txt")
+		if !errors.Is(err, fs.ErrInvalid) {
+			t.Errorf("expect error: %v, got: %v", fs.ErrInvalid, err)
+		}
+	})
+
+	t.Run("Open with FollowSymlinks rejects targets escaping the root", func(t *testing.T) {
+		gfs := NewWithOptions(tree, Options{FollowSymlinks: true})
+		_, err := gfs.Open("traverse.txt")
+		if !errors.Is(err, fs.ErrInvalid) {
+			t.Errorf("expect error: %v, got: %v", fs.ErrInvalid, err)
+		}
+	})
+
+	t.Run("Open with FollowSymlinks resolves a directory symlink mid-path", func(t *testing.T) {
+		gfs := NewWithOptions(tree, Options{FollowSymlinks: true})
+		data, err := fs.ReadFile(gfs, "linkdir/file.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "in realdir" {
+			t.Errorf("expect: %s, got: %s", "in realdir", string(data))
+		}
+	})
+
+	t.Run("Open without FollowSymlinks fails on a directory symlink mid-path", func(t *testing.T) {
+		_, err := gfs.Open("linkdir/file.txt")
+		if !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("expect error: %v, got: %v", fs.ErrNotExist, err)
+		}
+	})
+}
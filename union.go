@@ -0,0 +1,108 @@
+package gitfs
+
+import (
+	"errors"
+	"io/fs"
+	"sort"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// unionFS overlays a stack of filesystem layers, earlier layers taking
+// precedence on path collisions; see NewUnion.
+type unionFS struct {
+	layers []fs.FS
+}
+
+// NewUnion returns an fs.FS that overlays trees as a single filesystem,
+// afero-style: earlier trees take precedence on path collisions. Open
+// and Stat return the first layer containing the path. ReadDir merges
+// and de-duplicates entries across every layer that has the directory,
+// keeping the entry from the earliest layer on a name collision. Sub
+// returns another union rooted at the corresponding subtrees, skipping
+// layers where the subpath doesn't exist.
+//
+// This lets callers such as review UIs and diff viewers walk a "base ∪
+// head" or "left ∪ right" view as a single fs.FS, or serve an overlaid
+// virtual-branch snapshot without materialising a merge commit.
+func NewUnion(trees ...*object.Tree) fs.FS {
+	layers := make([]fs.FS, len(trees))
+	for i, tree := range trees {
+		layers[i] = New(tree)
+	}
+	return &unionFS{layers: layers}
+}
+
+func (u *unionFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, toFSError("open", name, fs.ErrInvalid)
+	}
+
+	var err error
+	for _, layer := range u.layers {
+		var f fs.File
+		if f, err = layer.Open(name); err == nil {
+			return f, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	if err == nil {
+		err = toFSError("open", name, fs.ErrNotExist)
+	}
+	return nil, err
+}
+
+func (u *unionFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, toFSError("readdir", name, fs.ErrInvalid)
+	}
+
+	var merged []fs.DirEntry
+	seen := make(map[string]bool)
+	found := false
+	for _, layer := range u.layers {
+		entries, err := fs.ReadDir(layer, name)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		found = true
+		for _, e := range entries {
+			if seen[e.Name()] {
+				continue
+			}
+			seen[e.Name()] = true
+			merged = append(merged, e)
+		}
+	}
+	if !found {
+		return nil, toFSError("readdir", name, fs.ErrNotExist)
+	}
+
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Name() < merged[j].Name() })
+	return merged, nil
+}
+
+// Sub returns an FS corresponding to the subtree rooted at dir, unioned
+// across every layer that has it.
+func (u *unionFS) Sub(dir string) (fs.FS, error) {
+	var layers []fs.FS
+	for _, layer := range u.layers {
+		sub, err := fs.Sub(layer, dir)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		layers = append(layers, sub)
+	}
+	if len(layers) == 0 {
+		return nil, toFSError("sub", dir, fs.ErrNotExist)
+	}
+	return &unionFS{layers: layers}, nil
+}
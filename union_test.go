@@ -0,0 +1,109 @@
+package gitfs
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+func TestUnionFS(t *testing.T) {
+	s := memory.NewStorage()
+
+	baseReadme := storeBlob(t, s, "# base")
+	baseOnly := storeBlob(t, s, "base only")
+	baseDir := storeTree(t, s, []object.TreeEntry{
+		{Name: "base.txt", Mode: filemode.Regular, Hash: baseOnly},
+	})
+	base := storeTree(t, s, []object.TreeEntry{
+		{Name: "README.md", Mode: filemode.Regular, Hash: baseReadme},
+		{Name: "dir", Mode: filemode.Dir, Hash: baseDir.Hash},
+	})
+
+	headReadme := storeBlob(t, s, "# head")
+	headOnly := storeBlob(t, s, "head only")
+	headDir := storeTree(t, s, []object.TreeEntry{
+		{Name: "head.txt", Mode: filemode.Regular, Hash: headOnly},
+	})
+	head := storeTree(t, s, []object.TreeEntry{
+		{Name: "README.md", Mode: filemode.Regular, Hash: headReadme},
+		{Name: "dir", Mode: filemode.Dir, Hash: headDir.Hash},
+	})
+
+	t.Run("Open and ReadFile prefer the first tree on collision", func(t *testing.T) {
+		u := NewUnion(head, base)
+
+		data, err := fs.ReadFile(u, "README.md")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "# head" {
+			t.Errorf("expect: %s, got: %s", "# head", string(data))
+		}
+	})
+
+	t.Run("Open falls through to a later tree", func(t *testing.T) {
+		u := NewUnion(head, base)
+
+		data, err := fs.ReadFile(u, "dir/base.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "base only" {
+			t.Errorf("expect: %s, got: %s", "base only", string(data))
+		}
+	})
+
+	t.Run("Open returns ErrNotExist when no tree has the path", func(t *testing.T) {
+		u := NewUnion(head, base)
+
+		_, err := fs.ReadFile(u, "nope.txt")
+		if !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("expect ErrNotExist, got: %v", err)
+		}
+	})
+
+	t.Run("ReadDir merges and de-duplicates by name", func(t *testing.T) {
+		u := NewUnion(head, base)
+
+		entries, err := fs.ReadDir(u, "dir")
+		if err != nil {
+			t.Fatal(err)
+		}
+		names := entryNames(entries)
+		if !contains(names, "head.txt") || !contains(names, "base.txt") {
+			t.Errorf("expect head.txt and base.txt in %v", names)
+		}
+
+		rootEntries, err := fs.ReadDir(u, ".")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rootEntries) != 2 {
+			t.Errorf("expect README.md and dir deduplicated, got: %v", entryNames(rootEntries))
+		}
+	})
+
+	t.Run("Sub unions the corresponding subtrees, skipping trees missing it", func(t *testing.T) {
+		u := NewUnion(head, base)
+
+		sub, err := fs.Sub(u, "dir")
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := fs.ReadFile(sub, "base.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "base only" {
+			t.Errorf("expect: %s, got: %s", "base only", string(data))
+		}
+
+		if _, err := fs.Sub(u, "nope"); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("expect ErrNotExist, got: %v", err)
+		}
+	})
+}